@@ -0,0 +1,104 @@
+package updater
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/letsencrypt/boulder/issuance"
+)
+
+// LeaseManager coordinates which crl-updater replica is allowed to generate
+// the CRL for a given issuer+shard during the current tick. It exists so
+// that crl-updater can be run on more than one host for HA without two
+// replicas double-generating the same shard's CRL or racing each other's
+// uploads to the storer.
+type LeaseManager interface {
+	// AcquireLease attempts to take out (or renew) the lease on the given
+	// issuer+shard for ttl, identifying owner as the holder. It returns true
+	// if the lease was acquired or renewed, and false if some other owner
+	// currently holds an unexpired lease on that issuer+shard.
+	AcquireLease(ctx context.Context, issuerNameID issuance.IssuerNameID, shardIdx int, owner string, ttl time.Duration) (bool, error)
+}
+
+// noopLeaseManager is the default LeaseManager for deployments which only
+// ever run a single crl-updater replica, where lease coordination would be
+// pure overhead: every lease is granted immediately.
+type noopLeaseManager struct{}
+
+func (noopLeaseManager) AcquireLease(_ context.Context, _ issuance.IssuerNameID, _ int, _ string, _ time.Duration) (bool, error) {
+	return true, nil
+}
+
+// newReplicaID generates a random identifier for this crl-updater process to
+// use as the owner of any leases it acquires.
+func newReplicaID() (string, error) {
+	buf := make([]byte, 8)
+	_, err := rand.Read(buf)
+	if err != nil {
+		return "", fmt.Errorf("generating random replica ID: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// MySQLLeaseManager is a LeaseManager backed by a MySQL table, used to
+// coordinate horizontally-scaled crl-updater deployments over the database
+// they already share. It expects a `crlShardLeases` table keyed on
+// (issuerID, shardIdx), storing the current owner and the time the lease
+// expires:
+//
+//	CREATE TABLE crlShardLeases (
+//	    issuerID BIGINT NOT NULL,
+//	    shardIdx BIGINT NOT NULL,
+//	    owner VARCHAR(255) NOT NULL,
+//	    expires DATETIME NOT NULL,
+//	    PRIMARY KEY (issuerID, shardIdx)
+//	);
+type MySQLLeaseManager struct {
+	db *sql.DB
+}
+
+// NewMySQLLeaseManager returns a LeaseManager backed by the given database
+// handle.
+func NewMySQLLeaseManager(db *sql.DB) *MySQLLeaseManager {
+	return &MySQLLeaseManager{db: db}
+}
+
+func (m *MySQLLeaseManager) AcquireLease(ctx context.Context, issuerNameID issuance.IssuerNameID, shardIdx int, owner string, ttl time.Duration) (bool, error) {
+	// Expiry is computed and compared using the database server's own clock
+	// (NOW()), not this replica's wall clock. Replicas racing to acquire the
+	// same lease may have arbitrarily skewed clocks; if expiry were judged
+	// locally, a replica running fast could steal a lease that its rightful,
+	// slower-clocked holder still considers valid, and both would generate
+	// the same shard's CRL at once. A single, DB-authoritative clock avoids
+	// that.
+	ttlSeconds := int64(ttl / time.Second)
+
+	// Take out the lease if there is none yet, or renew it in place if we
+	// already own it, or steal it if it has expired. Otherwise leave the
+	// existing (unexpired, foreign-owned) row untouched.
+	_, err := m.db.ExecContext(ctx, `
+		INSERT INTO crlShardLeases (issuerID, shardIdx, owner, expires)
+		VALUES (?, ?, ?, NOW() + INTERVAL ? SECOND)
+		ON DUPLICATE KEY UPDATE
+			owner = IF(expires <= NOW() OR owner = ?, VALUES(owner), owner),
+			expires = IF(expires <= NOW() OR owner = ?, VALUES(expires), expires)`,
+		int64(issuerNameID), shardIdx, owner, ttlSeconds,
+		owner, owner)
+	if err != nil {
+		return false, fmt.Errorf("acquiring lease for issuer %d shard %d: %w", issuerNameID, shardIdx, err)
+	}
+
+	var gotOwner string
+	err = m.db.QueryRowContext(ctx, `
+		SELECT owner FROM crlShardLeases WHERE issuerID = ? AND shardIdx = ?`,
+		int64(issuerNameID), shardIdx).Scan(&gotOwner)
+	if err != nil {
+		return false, fmt.Errorf("confirming lease for issuer %d shard %d: %w", issuerNameID, shardIdx, err)
+	}
+
+	return gotOwner == owner, nil
+}
@@ -0,0 +1,120 @@
+package updater
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+
+	"github.com/letsencrypt/boulder/issuance"
+	"github.com/letsencrypt/boulder/test"
+	"github.com/letsencrypt/boulder/test/vars"
+)
+
+// newTestLeaseDB opens a connection to the test database and ensures the
+// crlShardLeases table exists. It does not truncate the table: callers
+// should scope their rows to a unique issuerNameID so that test cases don't
+// interfere with each other.
+func newTestLeaseDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("mysql", vars.DBConnSAFullPerms)
+	test.AssertNotError(t, err, "opening test database")
+	t.Cleanup(func() { db.Close() })
+
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS crlShardLeases (
+			issuerID BIGINT NOT NULL,
+			shardIdx BIGINT NOT NULL,
+			owner VARCHAR(255) NOT NULL,
+			expires DATETIME NOT NULL,
+			PRIMARY KEY (issuerID, shardIdx)
+		)`)
+	test.AssertNotError(t, err, "creating crlShardLeases table")
+
+	return db
+}
+
+func TestNoopLeaseManagerAlwaysGrants(t *testing.T) {
+	var lm LeaseManager = noopLeaseManager{}
+
+	acquired, err := lm.AcquireLease(context.Background(), issuance.IssuerNameID(1), 0, "owner", time.Second)
+	test.AssertNotError(t, err, "AcquireLease")
+	test.Assert(t, acquired, "noop lease manager should always grant the lease")
+}
+
+func TestNewReplicaID(t *testing.T) {
+	a, err := newReplicaID()
+	test.AssertNotError(t, err, "newReplicaID")
+	test.Assert(t, a != "", "replica ID should not be empty")
+
+	b, err := newReplicaID()
+	test.AssertNotError(t, err, "newReplicaID")
+	test.Assert(t, a != b, "replica IDs should be randomly generated")
+}
+
+func TestMySQLLeaseManagerAcquiresUnheldLease(t *testing.T) {
+	db := newTestLeaseDB(t)
+	lm := NewMySQLLeaseManager(db)
+	issuerNameID := issuance.IssuerNameID(1001)
+
+	acquired, err := lm.AcquireLease(context.Background(), issuerNameID, 3, "replica-a", time.Minute)
+	test.AssertNotError(t, err, "AcquireLease")
+	test.Assert(t, acquired, "expected lease to be acquired when no row exists yet")
+}
+
+func TestMySQLLeaseManagerRenewsOwnLease(t *testing.T) {
+	db := newTestLeaseDB(t)
+	lm := NewMySQLLeaseManager(db)
+	issuerNameID := issuance.IssuerNameID(1002)
+
+	acquired, err := lm.AcquireLease(context.Background(), issuerNameID, 3, "replica-a", time.Minute)
+	test.AssertNotError(t, err, "AcquireLease")
+	test.Assert(t, acquired, "expected the first acquisition to succeed")
+
+	acquired, err = lm.AcquireLease(context.Background(), issuerNameID, 3, "replica-a", time.Minute)
+	test.AssertNotError(t, err, "AcquireLease")
+	test.Assert(t, acquired, "expected the current owner to be able to renew its own lease")
+}
+
+func TestMySQLLeaseManagerDeniesLeaseHeldByAnotherReplica(t *testing.T) {
+	db := newTestLeaseDB(t)
+	lm := NewMySQLLeaseManager(db)
+	issuerNameID := issuance.IssuerNameID(1003)
+
+	acquired, err := lm.AcquireLease(context.Background(), issuerNameID, 3, "replica-a", time.Minute)
+	test.AssertNotError(t, err, "AcquireLease")
+	test.Assert(t, acquired, "expected the first acquisition to succeed")
+
+	acquired, err = lm.AcquireLease(context.Background(), issuerNameID, 3, "replica-b", time.Minute)
+	test.AssertNotError(t, err, "AcquireLease")
+	test.Assert(t, !acquired, "expected lease to be denied while another replica holds it unexpired")
+}
+
+func TestMySQLLeaseManagerStealsExpiredLease(t *testing.T) {
+	db := newTestLeaseDB(t)
+	lm := NewMySQLLeaseManager(db)
+	issuerNameID := issuance.IssuerNameID(1004)
+
+	// A negative TTL lands the lease's expiry in the past as judged by the
+	// database server, so the lease is already expired by the time
+	// replica-b asks for it.
+	acquired, err := lm.AcquireLease(context.Background(), issuerNameID, 3, "replica-a", -time.Second)
+	test.AssertNotError(t, err, "AcquireLease")
+	test.Assert(t, acquired, "expected the first acquisition to succeed")
+
+	acquired, err = lm.AcquireLease(context.Background(), issuerNameID, 3, "replica-b", time.Minute)
+	test.AssertNotError(t, err, "AcquireLease")
+	test.Assert(t, acquired, "expected a replica to be able to steal an already-expired lease")
+}
+
+func TestMySQLLeaseManagerPropagatesExecError(t *testing.T) {
+	db := newTestLeaseDB(t)
+	lm := NewMySQLLeaseManager(db)
+	db.Close()
+
+	_, err := lm.AcquireLease(context.Background(), issuance.IssuerNameID(1005), 3, "replica-a", time.Minute)
+	test.AssertError(t, err, "expected the underlying exec error to be surfaced once the database is closed")
+}
@@ -3,12 +3,15 @@ package updater
 import (
 	"context"
 	"crypto/sha256"
+	"errors"
 	"fmt"
 	"io"
+	"math/rand"
 	"time"
 
 	"github.com/jmhodges/clock"
 	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
 
 	capb "github.com/letsencrypt/boulder/ca/proto"
 	"github.com/letsencrypt/boulder/crl"
@@ -19,13 +22,25 @@ import (
 )
 
 type crlUpdater struct {
-	issuers           map[issuance.IssuerNameID]*issuance.Certificate
-	numShards         int
-	lookbackPeriod    time.Duration
-	lookforwardPeriod time.Duration
-	updatePeriod      time.Duration
-	updateOffset      time.Duration
-	maxParallelism    int
+	issuers              map[issuance.IssuerNameID]*issuance.Certificate
+	numShards            int
+	lookbackPeriod       time.Duration
+	lookforwardPeriod    time.Duration
+	updatePeriod         time.Duration
+	updateOffset         time.Duration
+	maxParallelism       int
+	maxIssuerParallelism int
+	crlChunkSize         int
+	maxCRLSize           int
+	shardRetries         int
+	shardBackoff         time.Duration
+
+	// replicaID identifies this crl-updater process as the owner of any
+	// leases it acquires via leaseManager, so that multiple replicas can tell
+	// their own leases apart from one another's.
+	replicaID     string
+	shardLeaseTTL time.Duration
+	leaseManager  LeaseManager
 
 	sa sapb.StorageAuthorityClient
 	ca capb.CRLGeneratorClient
@@ -34,11 +49,65 @@ type crlUpdater struct {
 	tickHistogram       *prometheus.HistogramVec
 	updatedCounter      *prometheus.CounterVec
 	secondsSinceSuccess *prometheus.GaugeVec
+	crlSizeHistogram    *prometheus.HistogramVec
+	shardAttempts       *prometheus.CounterVec
 
 	log blog.Logger
 	clk clock.Clock
 }
 
+// errShardLeaseUnavailable is returned by tickShard when another crl-updater
+// replica currently holds the lease on the requested issuer+shard. It is not
+// a real failure: callers should treat it as "nothing to do here."
+var errShardLeaseUnavailable = errors.New("shard lease held by another replica")
+
+// maxShardBackoff caps the delay between shard retries, regardless of how
+// shardBackoff and shardRetries are configured, so that a large attempt
+// count can never overflow the exponential backoff computation below.
+const maxShardBackoff = 10 * time.Minute
+
+// shardRetryBackoff computes the (pre-jitter) delay before the given retry
+// attempt (0-indexed) of a shard, doubling base each time, capped at
+// maxShardBackoff.
+func shardRetryBackoff(base time.Duration, attempt int) time.Duration {
+	// Cap the exponent itself, rather than just the result, so that
+	// 1<<exponent can't overflow int64 before we ever get a chance to
+	// compare it against maxShardBackoff.
+	const maxExponent = 30
+	exponent := attempt
+	if exponent > maxExponent {
+		exponent = maxExponent
+	}
+
+	// base * (1<<exponent) can still overflow int64 for any base larger than
+	// a handful of seconds, and the wraparound isn't guaranteed to land
+	// outside (0, maxShardBackoff]. Check for overflow before multiplying,
+	// rather than only clamping after the fact.
+	if base <= 0 || base > maxShardBackoff>>uint(exponent) {
+		return maxShardBackoff
+	}
+
+	backoff := base * time.Duration(int64(1)<<uint(exponent))
+	if backoff <= 0 || backoff > maxShardBackoff {
+		backoff = maxShardBackoff
+	}
+	return backoff
+}
+
+// CRLClientDialOptions returns the gRPC dial options that the CA and
+// CRLStorer client connections passed to NewUpdater must be dialed with.
+// They raise the default ~4MiB gRPC message cap to maxCRLSize, so that a
+// large issuer's full shard CRL isn't rejected with ResourceExhausted on its
+// way between the CA, this updater, and the CRLStorer.
+func CRLClientDialOptions(maxCRLSize int) []grpc.DialOption {
+	return []grpc.DialOption{
+		grpc.WithDefaultCallOptions(
+			grpc.MaxCallRecvMsgSize(maxCRLSize),
+			grpc.MaxCallSendMsgSize(maxCRLSize),
+		),
+	}
+}
+
 func NewUpdater(
 	issuers []*issuance.Certificate,
 	numShards int,
@@ -46,6 +115,13 @@ func NewUpdater(
 	updatePeriod time.Duration,
 	updateOffset time.Duration,
 	maxParallelism int,
+	maxIssuerParallelism int,
+	crlChunkSize int,
+	maxCRLSize int,
+	shardRetries int,
+	shardBackoff time.Duration,
+	shardLeaseTTL time.Duration,
+	leaseManager LeaseManager,
 	sa sapb.StorageAuthorityClient,
 	ca capb.CRLGeneratorClient,
 	cs cspb.CRLStorerClient,
@@ -53,6 +129,10 @@ func NewUpdater(
 	log blog.Logger,
 	clk clock.Clock,
 ) (*crlUpdater, error) {
+	// The ca and cs clients passed in here must have been dialed with the
+	// options returned by CRLClientDialOptions(maxCRLSize), or a full shard's
+	// CRL can exceed gRPC's default 4MiB message cap before it ever reaches
+	// this updater.
 	issuersByNameID := make(map[issuance.IssuerNameID]*issuance.Certificate, len(issuers))
 	for _, issuer := range issuers {
 		issuersByNameID[issuer.NameID()] = issuer
@@ -94,6 +174,49 @@ func NewUpdater(
 		maxParallelism = 1
 	}
 
+	if maxIssuerParallelism <= 0 {
+		maxIssuerParallelism = 1
+	}
+
+	if maxCRLSize <= 0 {
+		return nil, fmt.Errorf("must have positive max CRL size, got: %d", maxCRLSize)
+	}
+
+	if crlChunkSize <= 0 {
+		return nil, fmt.Errorf("must have positive CRL chunk size, got: %d", crlChunkSize)
+	}
+
+	if crlChunkSize > maxCRLSize {
+		return nil, fmt.Errorf("CRL chunk size must be <= max CRL size: %d > %d", crlChunkSize, maxCRLSize)
+	}
+
+	if shardRetries < 0 {
+		return nil, fmt.Errorf("must have non-negative number of shard retries, got: %d", shardRetries)
+	}
+
+	if shardBackoff <= 0 && shardRetries > 0 {
+		return nil, fmt.Errorf("must have positive shard backoff when shard retries are enabled, got: %s", shardBackoff)
+	}
+
+	if leaseManager == nil {
+		// Most deployments only run a single crl-updater replica, so lease
+		// coordination is unnecessary: fall back to a manager which always
+		// grants the lease.
+		leaseManager = noopLeaseManager{}
+	}
+
+	if shardLeaseTTL < time.Second {
+		// MySQLLeaseManager computes lease expiry with one-second precision,
+		// so a sub-second TTL would truncate to zero and make the lease
+		// immediately stealable by a concurrently-renewing replica.
+		return nil, fmt.Errorf("shard lease TTL must be at least one second, got: %s", shardLeaseTTL)
+	}
+
+	replicaID, err := newReplicaID()
+	if err != nil {
+		return nil, fmt.Errorf("generating replica ID: %w", err)
+	}
+
 	tickHistogram := prometheus.NewHistogramVec(prometheus.HistogramOpts{
 		Name:    "crl_updater_ticks",
 		Help:    "A histogram of crl-updater tick latencies labeled by issuer and result",
@@ -113,6 +236,19 @@ func NewUpdater(
 	}, []string{"issuer"})
 	stats.MustRegister(secondsSinceSuccess)
 
+	crlSizeHistogram := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "crl_updater_crl_shard_bytes",
+		Help:    "A histogram of per-shard CRL sizes, in bytes, labeled by issuer",
+		Buckets: []float64{1024, 1024 * 10, 1024 * 100, 1024 * 1024, 1024 * 1024 * 4, 1024 * 1024 * 16, 1024 * 1024 * 64, 1024 * 1024 * 256},
+	}, []string{"issuer"})
+	stats.MustRegister(crlSizeHistogram)
+
+	shardAttempts := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "crl_updater_shard_attempts",
+		Help: "A counter of individual shard generation attempts labeled by issuer and result",
+	}, []string{"issuer", "result"})
+	stats.MustRegister(shardAttempts)
+
 	return &crlUpdater{
 		issuersByNameID,
 		numShards,
@@ -121,12 +257,22 @@ func NewUpdater(
 		updatePeriod,
 		updateOffset,
 		maxParallelism,
+		maxIssuerParallelism,
+		crlChunkSize,
+		maxCRLSize,
+		shardRetries,
+		shardBackoff,
+		replicaID,
+		shardLeaseTTL,
+		leaseManager,
 		sa,
 		ca,
 		cs,
 		tickHistogram,
 		updatedCounter,
 		secondsSinceSuccess,
+		crlSizeHistogram,
+		shardAttempts,
 		log,
 		clk,
 	}, nil
@@ -185,17 +331,39 @@ func (cu *crlUpdater) Tick(ctx context.Context) {
 	}()
 	cu.log.Debugf("Ticking at time %s", atTime)
 
-	for id, iss := range cu.issuers {
-		// For now, process each issuer serially. This keeps the worker pool system
-		// simple, and processing all of the issuers in parallel likely wouldn't
-		// meaningfully speed up the overall process.
-		err := cu.tickIssuer(ctx, atTime, id)
-		if err != nil {
+	type issuerResult struct {
+		issuerNameID issuance.IssuerNameID
+		err          error
+	}
+
+	issuerWorker := func(in <-chan issuance.IssuerNameID, out chan<- issuerResult) {
+		for id := range in {
+			out <- issuerResult{issuerNameID: id, err: cu.tickIssuer(ctx, atTime, id)}
+		}
+	}
+
+	// Fan the issuers themselves out across a second worker pool, sized by
+	// maxIssuerParallelism, separate from the per-issuer maxParallelism used to
+	// fan out shards within a single issuer's tick below.
+	issuerIDs := make(chan issuance.IssuerNameID, len(cu.issuers))
+	issuerResults := make(chan issuerResult, len(cu.issuers))
+	for i := 0; i < cu.maxIssuerParallelism; i++ {
+		go issuerWorker(issuerIDs, issuerResults)
+	}
+
+	for id := range cu.issuers {
+		issuerIDs <- id
+	}
+	close(issuerIDs)
+
+	for i := 0; i < len(cu.issuers); i++ {
+		res := <-issuerResults
+		if res.err != nil {
 			cu.log.AuditErrf(
 				"tick for issuer %s at time %s failed: %s",
-				iss.Subject.CommonName,
+				cu.issuers[res.issuerNameID].Subject.CommonName,
 				atTime.Format(time.RFC3339Nano),
-				err)
+				res.err)
 			result = "failed"
 		}
 	}
@@ -217,14 +385,11 @@ func (cu *crlUpdater) tickIssuer(ctx context.Context, atTime time.Time, issuerNa
 
 	shardWorker := func(in <-chan int, out chan<- shardResult) {
 		for idx := range in {
-			select {
-			case <-ctx.Done():
-				return
-			default:
-				out <- shardResult{
-					shardIdx: idx,
-					err:      cu.tickShard(ctx, atTime, issuerNameID, idx),
-				}
+			out <- shardResult{
+				shardIdx: idx,
+				err: cu.tickShardWithRetries(ctx, cu.issuers[issuerNameID].Subject.CommonName, idx, func(ctx context.Context, shardIdx int) error {
+					return cu.tickShard(ctx, atTime, issuerNameID, shardIdx)
+				}),
 			}
 		}
 	}
@@ -240,46 +405,168 @@ func (cu *crlUpdater) tickIssuer(ctx context.Context, atTime time.Time, issuerNa
 	}
 	close(shardIdxs)
 
+	// Drain every shard's final result, even if some failed, so that a
+	// transient problem with one shard doesn't prevent the rest of the
+	// issuer's shards from being refreshed.
+	var errs []error
 	for i := 0; i < cu.numShards; i++ {
 		res := <-shardResults
 		if res.err != nil {
+			errs = append(errs, fmt.Errorf("shard %d: %w", res.shardIdx, res.err))
+		}
+	}
+
+	if len(errs) > 0 {
+		result = "failed"
+		return fmt.Errorf("updating %d of %d shard(s) failed: %w", len(errs), cu.numShards, errors.Join(errs...))
+	}
+
+	return nil
+}
+
+// tickShardWithRetries calls tick (in production, a closure over cu.tickShard)
+// for a single shard, retrying failures with jittered exponential backoff up
+// to cu.shardRetries times, so that a single transient SA or storer error
+// doesn't take down the whole issuer's tick. It records the shard's final,
+// post-retry outcome on tickHistogram/updatedCounter (so those metrics keep
+// meaning "one shard, one outcome" regardless of how many attempts it took),
+// and each individual attempt's outcome on shardAttempts.
+func (cu *crlUpdater) tickShardWithRetries(ctx context.Context, issuerCN string, shardIdx int, tick func(ctx context.Context, shardIdx int) error) error {
+	start := cu.clk.Now()
+	result := "success"
+	defer func() {
+		cu.tickHistogram.WithLabelValues(issuerCN, result).Observe(cu.clk.Since(start).Seconds())
+		cu.updatedCounter.WithLabelValues(result).Inc()
+	}()
+
+	var err error
+	for attempt := 0; ; attempt++ {
+		if ctx.Err() != nil {
 			result = "failed"
-			return fmt.Errorf("updating shard %d: %w", res.shardIdx, res.err)
+			return ctx.Err()
+		}
+
+		err = tick(ctx, shardIdx)
+		if err == nil {
+			cu.shardAttempts.WithLabelValues(issuerCN, "success").Inc()
+			return nil
+		}
+		if errors.Is(err, errShardLeaseUnavailable) {
+			// Another replica holds the lease for this shard; that's not a
+			// failure, just a sign that this replica has nothing to do here.
+			cu.shardAttempts.WithLabelValues(issuerCN, "skipped_leased").Inc()
+			result = "skipped_leased"
+			return nil
+		}
+		cu.shardAttempts.WithLabelValues(issuerCN, "failed").Inc()
+
+		if attempt >= cu.shardRetries {
+			result = "failed"
+			return err
+		}
+
+		// Full jitter: sleep somewhere between 0 and backoff, so that a fleet
+		// of shard workers which all failed at the same time don't all retry
+		// in lockstep.
+		jittered := time.Duration(rand.Int63n(int64(shardRetryBackoff(cu.shardBackoff, attempt)) + 1))
+		select {
+		case <-ctx.Done():
+			result = "failed"
+			return ctx.Err()
+		case <-cu.clk.After(jittered):
 		}
 	}
+}
+
+// crlChunkBuffer batches small writes until at least chunkSize bytes have
+// accumulated before handing them to send, so that the number of outbound
+// gRPC messages doesn't scale with the (possibly much smaller) chunk size the
+// upstream CA happens to use internally. A chunk handed to send can exceed
+// chunkSize by up to the length of the write that filled it.
+type crlChunkBuffer struct {
+	chunkSize int
+	buf       []byte
+	send      func([]byte) error
+}
+
+func newCRLChunkBuffer(chunkSize int, send func([]byte) error) *crlChunkBuffer {
+	return &crlChunkBuffer{chunkSize: chunkSize, buf: make([]byte, 0, chunkSize), send: send}
+}
 
+// write appends p to the buffer, flushing to send once at least chunkSize
+// bytes have accumulated.
+func (b *crlChunkBuffer) write(p []byte) error {
+	b.buf = append(b.buf, p...)
+	if len(b.buf) >= b.chunkSize {
+		return b.flush()
+	}
 	return nil
 }
 
+// flush sends any buffered bytes which haven't been sent yet.
+func (b *crlChunkBuffer) flush() error {
+	if len(b.buf) == 0 {
+		return nil
+	}
+	err := b.send(b.buf)
+	b.buf = b.buf[:0]
+	return err
+}
+
 func (cu *crlUpdater) tickShard(ctx context.Context, atTime time.Time, issuerNameID issuance.IssuerNameID, shardIdx int) error {
-	start := cu.clk.Now()
 	crlId, err := crl.Id(issuerNameID, crl.Number(atTime), shardIdx)
 	if err != nil {
 		return err
 	}
-	result := "success"
-	defer func() {
-		cu.tickHistogram.WithLabelValues(cu.issuers[issuerNameID].Subject.CommonName, result).Observe(cu.clk.Since(start).Seconds())
-		cu.updatedCounter.WithLabelValues(result).Inc()
-	}()
 	cu.log.Debugf("Ticking shard %d of issuer %d at time %s", shardIdx, issuerNameID, atTime)
 
+	acquired, err := cu.leaseManager.AcquireLease(ctx, issuerNameID, shardIdx, cu.replicaID, cu.shardLeaseTTL)
+	if err != nil {
+		return fmt.Errorf("acquiring lease for %s: %w", crlId, err)
+	}
+	if !acquired {
+		cu.log.Debugf("Skipping shard %d of issuer %d at time %s: lease held by another replica", shardIdx, issuerNameID, atTime)
+		return errShardLeaseUnavailable
+	}
+
+	// Generating and uploading a shard's CRL can take significantly longer
+	// than a single shardLeaseTTL, especially for large shards. Renew the
+	// lease in the background for as long as this function is running, and
+	// cancel shardCtx (aborting the in-flight SA/CA/storer calls below) the
+	// moment we fail to renew it, so that we stop working on a shard as soon
+	// as another replica is legitimately allowed to pick it up.
+	shardCtx, cancelShard := context.WithCancel(ctx)
+	defer cancelShard()
+	go func() {
+		for {
+			select {
+			case <-shardCtx.Done():
+				return
+			case <-cu.clk.After(cu.shardLeaseTTL / 2):
+				renewed, err := cu.leaseManager.AcquireLease(shardCtx, issuerNameID, shardIdx, cu.replicaID, cu.shardLeaseTTL)
+				if err != nil || !renewed {
+					cu.log.Warningf("failed to renew lease for shard %d of issuer %d: %s", shardIdx, issuerNameID, err)
+					cancelShard()
+					return
+				}
+			}
+		}
+	}()
+
 	expiresAfter, expiresBefore := cu.getShardBoundaries(atTime, shardIdx)
 
-	saStream, err := cu.sa.GetRevokedCerts(ctx, &sapb.GetRevokedCertsRequest{
+	saStream, err := cu.sa.GetRevokedCerts(shardCtx, &sapb.GetRevokedCertsRequest{
 		IssuerNameID:  int64(issuerNameID),
 		ExpiresAfter:  expiresAfter.UnixNano(),
 		ExpiresBefore: expiresBefore.UnixNano(),
 		RevokedBefore: atTime.UnixNano(),
 	})
 	if err != nil {
-		result = "failed"
 		return fmt.Errorf("connecting to SA for %s: %w", crlId, err)
 	}
 
-	caStream, err := cu.ca.GenerateCRL(ctx)
+	caStream, err := cu.ca.GenerateCRL(shardCtx)
 	if err != nil {
-		result = "failed"
 		return fmt.Errorf("connecting to CA for %s: %w", crlId, err)
 	}
 
@@ -293,7 +580,6 @@ func (cu *crlUpdater) tickShard(ctx context.Context, atTime time.Time, issuerNam
 		},
 	})
 	if err != nil {
-		result = "failed"
 		return fmt.Errorf("sending CA metadata for %s: %w", crlId, err)
 	}
 
@@ -303,7 +589,6 @@ func (cu *crlUpdater) tickShard(ctx context.Context, atTime time.Time, issuerNam
 			if err == io.EOF {
 				break
 			}
-			result = "failed"
 			return fmt.Errorf("retrieving entry from SA for %s: %w", crlId, err)
 		}
 
@@ -313,7 +598,6 @@ func (cu *crlUpdater) tickShard(ctx context.Context, atTime time.Time, issuerNam
 			},
 		})
 		if err != nil {
-			result = "failed"
 			return fmt.Errorf("sending entry to CA for %s: %w", crlId, err)
 		}
 	}
@@ -323,13 +607,11 @@ func (cu *crlUpdater) tickShard(ctx context.Context, atTime time.Time, issuerNam
 	// in memory before it can sign it and start returning the real CRL.
 	err = caStream.CloseSend()
 	if err != nil {
-		result = "failed"
 		return fmt.Errorf("closing CA request stream for %s: %w", crlId, err)
 	}
 
-	csStream, err := cu.cs.UploadCRL(ctx)
+	csStream, err := cu.cs.UploadCRL(shardCtx)
 	if err != nil {
-		result = "failed"
 		return fmt.Errorf("connecting to CRLStorer for %s: %w", crlId, err)
 	}
 
@@ -343,41 +625,51 @@ func (cu *crlUpdater) tickShard(ctx context.Context, atTime time.Time, issuerNam
 		},
 	})
 	if err != nil {
-		result = "failed"
 		return fmt.Errorf("sending CRLStorer metadata for %s: %w", crlId, err)
 	}
 
 	crlLen := 0
 	crlHash := sha256.New()
+	// Buffer chunks from the CA until at least crlChunkSize bytes are held
+	// before forwarding them to the storer, so that the number of gRPC messages we send doesn't scale
+	// with the (much smaller) chunk size the CA happens to use internally.
+	chunkBuf := newCRLChunkBuffer(cu.crlChunkSize, func(chunk []byte) error {
+		return csStream.Send(&cspb.UploadCRLRequest{
+			Payload: &cspb.UploadCRLRequest_CrlChunk{
+				CrlChunk: chunk,
+			},
+		})
+	})
 	for {
 		out, err := caStream.Recv()
 		if err != nil {
 			if err == io.EOF {
 				break
 			}
-			result = "failed"
 			return fmt.Errorf("receiving CRL bytes for %s: %w", crlId, err)
 		}
 
-		err = csStream.Send(&cspb.UploadCRLRequest{
-			Payload: &cspb.UploadCRLRequest_CrlChunk{
-				CrlChunk: out.Chunk,
-			},
-		})
+		crlLen += len(out.Chunk)
+		if crlLen > cu.maxCRLSize {
+			return fmt.Errorf("generated CRL for %s exceeds max size: %d > %d", crlId, crlLen, cu.maxCRLSize)
+		}
+		crlHash.Write(out.Chunk)
+
+		err = chunkBuf.write(out.Chunk)
 		if err != nil {
-			result = "failed"
 			return fmt.Errorf("uploading CRL bytes for %s: %w", crlId, err)
 		}
-
-		crlLen += len(out.Chunk)
-		crlHash.Write(out.Chunk)
+	}
+	err = chunkBuf.flush()
+	if err != nil {
+		return fmt.Errorf("uploading CRL bytes for %s: %w", crlId, err)
 	}
 
+	cu.crlSizeHistogram.WithLabelValues(cu.issuers[issuerNameID].Subject.CommonName).Observe(float64(crlLen))
 	cu.log.Infof("Generated CRL: id=[%s] size=[%d] hash=[%x]", crlId, crlLen, crlHash.Sum(nil))
 
 	_, err = csStream.CloseAndRecv()
 	if err != nil {
-		result = "failed"
 		return fmt.Errorf("closing CRLStorer upload stream for %s: %w", crlId, err)
 	}
 
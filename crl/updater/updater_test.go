@@ -0,0 +1,266 @@
+package updater
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jmhodges/clock"
+	"github.com/prometheus/client_golang/prometheus"
+
+	blog "github.com/letsencrypt/boulder/log"
+	"github.com/letsencrypt/boulder/test"
+)
+
+// newUpdaterArgs holds NewUpdater's configuration arguments (callNewUpdater
+// supplies the issuers, clients, registerer, logger and clock), so that individual
+// validation tests can start from a known-good baseline and mutate just the
+// field they care about.
+type newUpdaterArgs struct {
+	numShards            int
+	certLifetime         time.Duration
+	updatePeriod         time.Duration
+	updateOffset         time.Duration
+	maxParallelism       int
+	maxIssuerParallelism int
+	crlChunkSize         int
+	maxCRLSize           int
+	shardRetries         int
+	shardBackoff         time.Duration
+	shardLeaseTTL        time.Duration
+	leaseManager         LeaseManager
+}
+
+func validNewUpdaterArgs() newUpdaterArgs {
+	return newUpdaterArgs{
+		numShards:            10,
+		certLifetime:         90 * 24 * time.Hour,
+		updatePeriod:         6 * time.Hour,
+		updateOffset:         0,
+		maxParallelism:       1,
+		maxIssuerParallelism: 1,
+		crlChunkSize:         1024,
+		maxCRLSize:           1024 * 1024,
+		shardRetries:         3,
+		shardBackoff:         time.Second,
+		shardLeaseTTL:        time.Minute,
+	}
+}
+
+func callNewUpdater(a newUpdaterArgs) (*crlUpdater, error) {
+	return NewUpdater(
+		nil,
+		a.numShards,
+		a.certLifetime,
+		a.updatePeriod,
+		a.updateOffset,
+		a.maxParallelism,
+		a.maxIssuerParallelism,
+		a.crlChunkSize,
+		a.maxCRLSize,
+		a.shardRetries,
+		a.shardBackoff,
+		a.shardLeaseTTL,
+		a.leaseManager,
+		nil,
+		nil,
+		nil,
+		prometheus.NewRegistry(),
+		blog.NewMock(),
+		clock.New(),
+	)
+}
+
+func TestNewUpdaterValidation(t *testing.T) {
+	cases := []struct {
+		name      string
+		mutate    func(*newUpdaterArgs)
+		wantError bool
+	}{
+		{"valid config", func(a *newUpdaterArgs) {}, false},
+		{"zero shards", func(a *newUpdaterArgs) { a.numShards = 0 }, true},
+		{"update period too long", func(a *newUpdaterArgs) { a.updatePeriod = 8 * 24 * time.Hour }, true},
+		{"offset not less than period", func(a *newUpdaterArgs) { a.updateOffset = a.updatePeriod }, true},
+		{"zero max CRL size", func(a *newUpdaterArgs) { a.maxCRLSize = 0 }, true},
+		{"zero CRL chunk size", func(a *newUpdaterArgs) { a.crlChunkSize = 0 }, true},
+		{"CRL chunk size exceeds max CRL size", func(a *newUpdaterArgs) { a.crlChunkSize = a.maxCRLSize + 1 }, true},
+		{"negative shard retries", func(a *newUpdaterArgs) { a.shardRetries = -1 }, true},
+		{"zero shard backoff with retries enabled", func(a *newUpdaterArgs) { a.shardBackoff = 0 }, true},
+		{"zero shard backoff with retries disabled", func(a *newUpdaterArgs) {
+			a.shardRetries = 0
+			a.shardBackoff = 0
+		}, false},
+		{"zero shard lease TTL", func(a *newUpdaterArgs) { a.shardLeaseTTL = 0 }, true},
+		{"sub-second shard lease TTL", func(a *newUpdaterArgs) { a.shardLeaseTTL = 500 * time.Millisecond }, true},
+		{"nil lease manager defaults to noop", func(a *newUpdaterArgs) { a.leaseManager = nil }, false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			args := validNewUpdaterArgs()
+			c.mutate(&args)
+
+			u, err := callNewUpdater(args)
+			if c.wantError {
+				test.AssertError(t, err, "expected error")
+				return
+			}
+			test.AssertNotError(t, err, "expected success")
+			if u == nil {
+				t.Fatal("expected a non-nil updater")
+			}
+		})
+	}
+}
+
+func TestCRLChunkBuffer(t *testing.T) {
+	var sent [][]byte
+	send := func(chunk []byte) error {
+		sent = append(sent, append([]byte(nil), chunk...))
+		return nil
+	}
+
+	buf := newCRLChunkBuffer(4, send)
+
+	err := buf.write([]byte{1, 2})
+	test.AssertNotError(t, err, "write")
+	test.AssertEquals(t, len(sent), 0)
+
+	err = buf.write([]byte{3, 4, 5})
+	test.AssertNotError(t, err, "write")
+	test.AssertEquals(t, len(sent), 1)
+	test.AssertDeepEquals(t, sent[0], []byte{1, 2, 3, 4, 5})
+
+	// Flushing an empty buffer is a no-op.
+	err = buf.flush()
+	test.AssertNotError(t, err, "flush")
+	test.AssertEquals(t, len(sent), 1)
+
+	err = buf.write([]byte{6})
+	test.AssertNotError(t, err, "write")
+	test.AssertEquals(t, len(sent), 1)
+
+	err = buf.flush()
+	test.AssertNotError(t, err, "flush")
+	test.AssertEquals(t, len(sent), 2)
+	test.AssertDeepEquals(t, sent[1], []byte{6})
+}
+
+func TestCRLChunkBufferSendError(t *testing.T) {
+	wantErr := errors.New("send failed")
+	buf := newCRLChunkBuffer(1, func([]byte) error { return wantErr })
+
+	err := buf.write([]byte{1})
+	test.AssertError(t, err, "expected write to surface the send error")
+	test.AssertEquals(t, err.Error(), wantErr.Error())
+}
+
+func TestShardRetryBackoff(t *testing.T) {
+	cases := []struct {
+		name    string
+		base    time.Duration
+		attempt int
+	}{
+		{"first attempt", time.Second, 0},
+		{"several attempts", time.Second, 5},
+		{"many attempts don't overflow", time.Second, 1000},
+		{"large base doesn't overflow", 1000 * time.Hour, 40},
+		{"minutes-scale base doesn't overflow", 395136991776 * time.Nanosecond, 30},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			backoff := shardRetryBackoff(c.base, c.attempt)
+			test.Assert(t, backoff > 0, "backoff should always be positive")
+			test.Assert(t, backoff <= maxShardBackoff, "backoff should never exceed maxShardBackoff")
+		})
+	}
+}
+
+// newTestCrlUpdater builds a crlUpdater with just the fields
+// tickShardWithRetries touches, so its retry/skip/fail decision tree can be
+// exercised without needing a real issuance.Certificate or gRPC clients.
+func newTestCrlUpdater(shardRetries int, shardBackoff time.Duration) *crlUpdater {
+	reg := prometheus.NewRegistry()
+	tickHistogram := prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: "test_tick"}, []string{"issuer", "result"})
+	updatedCounter := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_generated"}, []string{"result"})
+	shardAttempts := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_attempts"}, []string{"issuer", "result"})
+	reg.MustRegister(tickHistogram, updatedCounter, shardAttempts)
+
+	return &crlUpdater{
+		shardRetries:   shardRetries,
+		shardBackoff:   shardBackoff,
+		tickHistogram:  tickHistogram,
+		updatedCounter: updatedCounter,
+		shardAttempts:  shardAttempts,
+		log:            blog.NewMock(),
+		clk:            clock.New(),
+	}
+}
+
+func TestTickShardWithRetriesSucceedsFirstTry(t *testing.T) {
+	cu := newTestCrlUpdater(3, time.Millisecond)
+
+	calls := 0
+	err := cu.tickShardWithRetries(context.Background(), "Test Issuer", 0, func(ctx context.Context, shardIdx int) error {
+		calls++
+		return nil
+	})
+	test.AssertNotError(t, err, "expected success")
+	test.AssertEquals(t, calls, 1)
+}
+
+func TestTickShardWithRetriesSucceedsAfterFailures(t *testing.T) {
+	cu := newTestCrlUpdater(3, time.Millisecond)
+
+	calls := 0
+	err := cu.tickShardWithRetries(context.Background(), "Test Issuer", 0, func(ctx context.Context, shardIdx int) error {
+		calls++
+		if calls <= 2 {
+			return errors.New("transient")
+		}
+		return nil
+	})
+	test.AssertNotError(t, err, "expected eventual success")
+	test.AssertEquals(t, calls, 3)
+}
+
+func TestTickShardWithRetriesExhausted(t *testing.T) {
+	cu := newTestCrlUpdater(2, time.Millisecond)
+
+	calls := 0
+	wantErr := errors.New("persistent")
+	err := cu.tickShardWithRetries(context.Background(), "Test Issuer", 0, func(ctx context.Context, shardIdx int) error {
+		calls++
+		return wantErr
+	})
+	test.AssertError(t, err, "expected failure once retries are exhausted")
+	test.AssertEquals(t, calls, 3) // one initial attempt plus two retries
+}
+
+func TestTickShardWithRetriesSkipsLeaseUnavailable(t *testing.T) {
+	cu := newTestCrlUpdater(3, time.Millisecond)
+
+	calls := 0
+	err := cu.tickShardWithRetries(context.Background(), "Test Issuer", 0, func(ctx context.Context, shardIdx int) error {
+		calls++
+		return errShardLeaseUnavailable
+	})
+	test.AssertNotError(t, err, "a held lease should not be treated as a failure")
+	test.AssertEquals(t, calls, 1) // no point retrying if another replica has the lease
+}
+
+func TestTickShardWithRetriesStopsOnCanceledContext(t *testing.T) {
+	cu := newTestCrlUpdater(3, time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	calls := 0
+	err := cu.tickShardWithRetries(ctx, "Test Issuer", 0, func(ctx context.Context, shardIdx int) error {
+		calls++
+		return nil
+	})
+	test.AssertError(t, err, "expected context cancellation to be surfaced")
+	test.AssertEquals(t, calls, 0)
+}